@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/harlow/go-micro-services/registry"
+	"github.com/harlow/go-micro-services/services/profile"
+)
+
+// profileConfig is the merged, validated view of everything the profile
+// service needs to start, regardless of whether it came from a flag, an
+// env var, or a config file.
+type profileConfig struct {
+	Port             int           `mapstructure:"port"`
+	PrometheusPort   int           `mapstructure:"prometheus_port"`
+	IpAddr           string        `mapstructure:"ip_addr"`
+	ConsulAddr       string        `mapstructure:"consul_addr"`
+	SinglePortMode   bool          `mapstructure:"single_port_mode"`
+	TracingBackend   string        `mapstructure:"tracing_backend"`
+	MongoURI         string        `mapstructure:"mongo_uri"`
+	MongoMaxPoolSize uint64        `mapstructure:"mongo_max_pool_size"`
+	MongoMinPoolSize uint64        `mapstructure:"mongo_min_pool_size"`
+	MongoMaxIdle     time.Duration `mapstructure:"mongo_max_conn_idle_time"`
+	MemcachedAddrs   []string      `mapstructure:"memcached_addrs"`
+}
+
+func init() {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the profile gRPC server",
+		RunE:  runProfileServe,
+	}
+	checkConfigCmd := &cobra.Command{
+		Use:   "check-config",
+		Short: "Validate connectivity to Mongo, Memcached, and Consul",
+		RunE:  runProfileCheckConfig,
+	}
+	for _, c := range []*cobra.Command{serveCmd, checkConfigCmd} {
+		addProfileConfigFlags(c)
+	}
+	serveCmd.Flags().Bool("dry-run", false, "print the effective merged config as YAML and exit")
+
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Run or inspect the profile service",
+	}
+	profileCmd.AddCommand(serveCmd, checkConfigCmd)
+	Root.AddCommand(profileCmd)
+}
+
+func addProfileConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().String("config", "", "path to a config file (YAML/JSON/TOML)")
+	cmd.Flags().Int("port", 8081, "gRPC port (also serves Prometheus in single-port mode)")
+	cmd.Flags().Int("prometheus-port", 9090, "Prometheus port, used unless single-port mode is enabled")
+	cmd.Flags().String("ip-addr", "", "address to register in Consul")
+	cmd.Flags().String("consul-addr", "127.0.0.1:8500", "Consul agent address")
+	cmd.Flags().Bool("single-port-mode", false, "serve gRPC and Prometheus together on port via h2c, instead of the legacy two-port topology")
+	cmd.Flags().String("tracing-backend", "opentracing", "opentracing or otel")
+	cmd.Flags().String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	cmd.Flags().Uint64("mongo-max-pool-size", 100, "MongoDB client max pool size")
+	cmd.Flags().Uint64("mongo-min-pool-size", 0, "MongoDB client min pool size")
+	cmd.Flags().Duration("mongo-max-conn-idle-time", 0, "MongoDB client max connection idle time")
+	cmd.Flags().StringSlice("memcached-addrs", []string{"localhost:11211"}, "memcached server addresses")
+}
+
+// loadProfileConfig merges flags, DSB_PROFILE_-prefixed env vars, and an
+// optional --config file into a profileConfig, in that precedence order
+// (Viper puts explicit flags above env vars above file values).
+func loadProfileConfig(cmd *cobra.Command) (*profileConfig, error) {
+	v := viper.New()
+	v.SetEnvPrefix("DSB_PROFILE")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+	if err := v.BindPFlags(cmd.Flags()); err != nil {
+		return nil, fmt.Errorf("failed to bind flags: %v", err)
+	}
+
+	if cfgFile, _ := cmd.Flags().GetString("config"); cfgFile != "" {
+		v.SetConfigFile(cfgFile)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file %q: %v", cfgFile, err)
+		}
+	}
+
+	cfg := &profileConfig{
+		Port:             v.GetInt("port"),
+		PrometheusPort:   v.GetInt("prometheus-port"),
+		IpAddr:           v.GetString("ip-addr"),
+		ConsulAddr:       v.GetString("consul-addr"),
+		SinglePortMode:   v.GetBool("single-port-mode"),
+		TracingBackend:   v.GetString("tracing-backend"),
+		MongoURI:         v.GetString("mongo-uri"),
+		MongoMaxPoolSize: v.GetUint64("mongo-max-pool-size"),
+		MongoMinPoolSize: v.GetUint64("mongo-min-pool-size"),
+		MongoMaxIdle:     v.GetDuration("mongo-max-conn-idle-time"),
+		MemcachedAddrs:   v.GetStringSlice("memcached-addrs"),
+	}
+	return cfg, nil
+}
+
+func runProfileServe(cmd *cobra.Command, args []string) error {
+	cfg, err := loadProfileConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		out, err := yaml.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to render config as YAML: %v", err)
+		}
+		fmt.Print(string(out))
+		return nil
+	}
+
+	srv, err := newProfileServer(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+	return srv.Run()
+}
+
+func runProfileCheckConfig(cmd *cobra.Command, args []string) error {
+	cfg, err := loadProfileConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+	defer cancel()
+
+	mongoClient, err := profile.NewMongoClient(ctx, profile.MongoConfig{
+		URI:             cfg.MongoURI,
+		MaxPoolSize:     cfg.MongoMaxPoolSize,
+		MinPoolSize:     cfg.MongoMinPoolSize,
+		MaxConnIdleTime: cfg.MongoMaxIdle,
+	})
+	if err != nil {
+		return fmt.Errorf("mongo: %v", err)
+	}
+	if err := mongoClient.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("mongo: ping failed: %v", err)
+	}
+
+	memcClient := memcache.New(cfg.MemcachedAddrs...)
+	if err := memcClient.Ping(); err != nil {
+		return fmt.Errorf("memcached: %v", err)
+	}
+
+	if _, err := registry.NewClient(cfg.ConsulAddr); err != nil {
+		return fmt.Errorf("consul: %v", err)
+	}
+
+	fmt.Println("ok: mongo, memcached, and consul are all reachable")
+	return nil
+}
+
+func newProfileServer(ctx context.Context, cfg *profileConfig) (*profile.Server, error) {
+	mongoClient, err := profile.NewMongoClient(ctx, profile.MongoConfig{
+		URI:             cfg.MongoURI,
+		MaxPoolSize:     cfg.MongoMaxPoolSize,
+		MinPoolSize:     cfg.MongoMinPoolSize,
+		MaxConnIdleTime: cfg.MongoMaxIdle,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongo: %v", err)
+	}
+
+	registryClient, err := registry.NewClient(cfg.ConsulAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to consul: %v", err)
+	}
+
+	if cfg.TracingBackend != "" {
+		// Server.Run reads TRACING_BACKEND itself via tracing.BackendFromEnv,
+		// so forward whatever Viper resolved (flag/env/file) into the
+		// process environment before Run is called.
+		os.Setenv("TRACING_BACKEND", cfg.TracingBackend)
+	}
+
+	return &profile.Server{
+		Port:           cfg.Port,
+		PrometheusPort: cfg.PrometheusPort,
+		IpAddr:         cfg.IpAddr,
+		SinglePortMode: cfg.SinglePortMode,
+		MongoClient:    mongoClient,
+		Registry:       registryClient,
+		MemcClient:     memcache.New(cfg.MemcachedAddrs...),
+	}, nil
+}
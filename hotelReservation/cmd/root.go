@@ -0,0 +1,27 @@
+// Package cmd wires service entrypoints onto a shared Cobra/Viper command
+// tree (`dsb <service> serve`) in place of a bespoke main.go plus
+// hand-rolled JSON config. The profile service (profile.go) is the only
+// service registered on it; no other service has a subcommand here.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Root is the top-level "dsb" command. Each service registers its own
+// subcommand on it from an init() in its own <service>.go file.
+var Root = &cobra.Command{
+	Use:   "dsb",
+	Short: "DeathStarBench hotel reservation service entrypoints",
+}
+
+// Execute runs Root, exiting the process with a non-zero status on error.
+func Execute() {
+	if err := Root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,79 @@
+// Package logging provides a shared log/slog setup so every service's log
+// records carry the trace/span ID of whatever request produced them, letting
+// Loki/Elastic joins land on the exact trace in Jaeger/Tempo.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/harlow/go-micro-services/tracing"
+)
+
+// New returns a logger whose handler, on every record, pulls the span active
+// in that record's context (set via DebugContext/InfoContext/ErrorContext,
+// etc.) and stamps it with trace_id/span_id attributes. ctx seeds the
+// logger's base attributes from whatever span is active when it's built;
+// call sites that want per-call correlation should still log through the
+// *Context methods.
+//
+// Output format is controlled by LOG_FORMAT (json|text, default text) and
+// level by LOG_LEVEL (debug|info|warn|error, default info).
+func New(ctx context.Context) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: levelFromEnv()}
+
+	var base slog.Handler
+	if os.Getenv("LOG_FORMAT") == "json" {
+		base = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		base = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	logger := slog.New(&traceHandler{Handler: base})
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		logger = logger.With(slog.String("trace_id", span.TraceID()))
+	}
+	return logger
+}
+
+func levelFromEnv() slog.Level {
+	switch os.Getenv("LOG_LEVEL") {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// traceHandler decorates another slog.Handler, adding trace_id/span_id
+// attributes pulled from the record's context. It works with whichever
+// tracing backend is active (OpenTracing or OTel), since both implement
+// tracing.Span.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		if traceID := span.TraceID(); traceID != "" {
+			r.AddAttrs(slog.String("trace_id", traceID))
+		}
+		if spanID := span.SpanID(); spanID != "" {
+			r.AddAttrs(slog.String("span_id", spanID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithGroup(name)}
+}
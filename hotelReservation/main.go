@@ -0,0 +1,7 @@
+package main
+
+import "github.com/harlow/go-micro-services/cmd"
+
+func main() {
+	cmd.Execute()
+}
@@ -0,0 +1,75 @@
+// Package otel constructs an OpenTelemetry TracerProvider exporting spans
+// over OTLP, for services migrating off OpenTracing/Jaeger.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// Protocol selects how spans are shipped to the collector.
+type Protocol string
+
+const (
+	ProtocolGRPC Protocol = "grpc"
+	ProtocolHTTP Protocol = "http"
+)
+
+// ProtocolFromEnv reads OTEL_EXPORTER_OTLP_PROTOCOL, defaulting to ProtocolGRPC.
+func ProtocolFromEnv() Protocol {
+	switch Protocol(os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")) {
+	case ProtocolHTTP:
+		return ProtocolHTTP
+	default:
+		return ProtocolGRPC
+	}
+}
+
+// NewTracerProvider builds a TracerProvider for serviceName whose exporter
+// endpoint and protocol are taken from the standard OTEL_EXPORTER_OTLP_*
+// environment variables. The returned shutdown func flushes and closes the
+// exporter and should be deferred by the caller.
+func NewTracerProvider(serviceName string) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exporter, err := newExporter(ctx, ProtocolFromEnv())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build OTel resource: %v", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, protocol Protocol) (sdktrace.SpanExporter, error) {
+	if protocol == ProtocolHTTP {
+		return otlptrace.New(ctx, otlptracehttp.NewClient())
+	}
+	return otlptrace.New(ctx, otlptracegrpc.NewClient())
+}
+
+// B3Propagator returns a B3 propagator so services can accept both W3C
+// traceparent and B3 headers while OpenTracing callers migrate.
+func B3Propagator() propagation.TextMapPropagator {
+	return b3.New()
+}
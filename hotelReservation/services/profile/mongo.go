@@ -0,0 +1,84 @@
+package profile
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoConfig controls the connection pool backing Server.MongoClient.
+type MongoConfig struct {
+	URI             string
+	MaxPoolSize     uint64
+	MinPoolSize     uint64
+	MaxConnIdleTime time.Duration
+}
+
+var (
+	mongoPoolCheckedOut = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mongo_pool_checkedout",
+		Help: "Connections currently checked out of the profile service's Mongo pool.",
+	})
+	mongoPoolAvailable = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mongo_pool_available",
+		Help: "Idle connections available in the profile service's Mongo pool.",
+	})
+)
+
+// NewMongoClient dials MongoDB with a pooled *mongo.Client configured from
+// cfg, reporting pool occupancy via the mongo_pool_* gauges.
+func NewMongoClient(ctx context.Context, cfg MongoConfig) (*mongo.Client, error) {
+	// created, checkedOut, and closed are tallied under poolStatsMu so
+	// mongoPoolAvailable (= created - checkedOut - closed) is always derived
+	// from a consistent snapshot. Deriving it this way, rather than
+	// incrementing/decrementing it directly on each event, keeps it correct
+	// when a checked-out connection is later closed (idle-timeout eviction,
+	// reset after an error, pool-clear on topology change) -- that's a
+	// checkedOut connection becoming a closed one, not an independent event.
+	var (
+		poolStatsMu     sync.Mutex
+		created, closed int64
+		checkedOut      int64
+	)
+	poolMonitor := &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			poolStatsMu.Lock()
+			defer poolStatsMu.Unlock()
+
+			switch evt.Type {
+			case event.ConnectionCheckedOut:
+				checkedOut++
+				mongoPoolCheckedOut.Inc()
+			case event.ConnectionCheckedIn:
+				checkedOut--
+				mongoPoolCheckedOut.Dec()
+			case event.ConnectionCreated:
+				created++
+			case event.ConnectionClosed:
+				closed++
+			default:
+				return
+			}
+			mongoPoolAvailable.Set(float64(created - checkedOut - closed))
+		},
+	}
+
+	clientOpts := options.Client().ApplyURI(cfg.URI).SetPoolMonitor(poolMonitor)
+	if cfg.MaxPoolSize > 0 {
+		clientOpts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.MinPoolSize > 0 {
+		clientOpts.SetMinPoolSize(cfg.MinPoolSize)
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		clientOpts.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+
+	return mongo.Connect(ctx, clientOpts)
+}
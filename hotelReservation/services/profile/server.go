@@ -2,48 +2,58 @@ package profile
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
-	// "io/ioutil"
 	"net"
-	// "os"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/bjornleffler/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"github.com/rs/zerolog/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/google/uuid"
-	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
+	"github.com/harlow/go-micro-services/logging"
 	"github.com/harlow/go-micro-services/registry"
 	pb "github.com/harlow/go-micro-services/services/profile/proto"
 	"github.com/harlow/go-micro-services/tls"
-	"github.com/opentracing/opentracing-go"
+	"github.com/harlow/go-micro-services/tracing"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
 
 	"github.com/bradfitz/gomemcache/memcache"
-	// "strings"
 )
 
 const name = "srv-profile"
 
 // Server implements the profile service
 type Server struct {
-	Tracer         opentracing.Tracer
+	Tracer         tracing.Tracer
 	uuid           string
 	Port           int
 	PrometheusPort int
 	IpAddr         string
-	MongoSession   *mgo.Session
+	MongoClient    *mongo.Client
 	Registry       *registry.Client
 	MemcClient     *memcache.Client
+	// SinglePortMode opts into serving gRPC and Prometheus together on Port
+	// via h2c instead of the legacy two-listener topology (gRPC on Port,
+	// Prometheus on PrometheusPort). It defaults to false -- matching the
+	// zero value -- so existing deployments that don't set it keep scraping
+	// PrometheusPort exactly as before.
+	SinglePortMode bool
 }
 
 var (
@@ -70,19 +80,86 @@ var (
 		Name: "server_exclusive_latency",
 		Help: "Server exclusive request latency, by method."},
 		[]string{"method"})
+
+	profileSingleflightShared = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "profile_singleflight_shared_total",
+		Help: "Mongo lookups served by a singleflight call already in flight for the same hotel ID."})
+	profileNegativeCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "profile_negative_cache_hits_total",
+		Help: "Memcached hits against the negative-cache sentinel for a hotel ID known not to exist."})
+)
+
+// hotelGroup deduplicates concurrent Mongo lookups for the same hotel ID
+// across requests, so a cold cache under load only hits Mongo once per ID.
+var hotelGroup singleflight.Group
+
+// negativeCacheValue and negativeCacheExpiration mark a hotel ID as looked up
+// and confirmed absent, so repeat requests skip Mongo entirely until the
+// sentinel expires.
+const (
+	negativeCacheValue      = "__missing__"
+	negativeCacheExpiration = int32(30)
 )
 
+// errHotelNotFound is returned by fetchHotelProfile when Mongo has no
+// document for the hotel ID; it is not logged as a failure.
+var errHotelNotFound = errors.New("hotel not found")
+
+var logger = logging.New(context.Background())
+
+// hotelLogValue adapts pb.Hotel to slog.LogValuer so it's logged consistently
+// (just its ID) wherever it appears as a log attribute.
+type hotelLogValue struct {
+	hotel *pb.Hotel
+}
+
+func (h hotelLogValue) LogValue() slog.Value {
+	if h.hotel == nil {
+		return slog.Value{}
+	}
+	return slog.StringValue(h.hotel.Id)
+}
+
 // Run starts the server
 func (s *Server) Run() error {
-	opentracing.SetGlobalTracer(s.Tracer)
-
 	if s.Port == 0 {
 		return fmt.Errorf("server port must be set")
 	}
 
 	s.uuid = uuid.New().String()
 
-	log.Trace().Msgf("in run s.IpAddr = %s, port = %d", s.IpAddr, s.Port)
+	logger.DebugContext(context.Background(), "starting profile server",
+		slog.String("ip_addr", s.IpAddr), slog.Int("port", s.Port))
+
+	// Configure tracing (TRACING_BACKEND=opentracing|otel). In two-port mode
+	// the separate Prometheus listener is stood up here (by tracing.Configure,
+	// or directly when s.Tracer is preset); in single-port mode /metrics is
+	// mounted on the shared mux below instead.
+	prometheusPort := 0
+	if !s.SinglePortMode {
+		prometheusPort = s.PrometheusPort
+	}
+	if s.Tracer == nil {
+		tracer, err := tracing.Configure(name, tracing.BackendFromEnv(), prometheusPort)
+		if err != nil {
+			return fmt.Errorf("failed to configure tracing: %v", err)
+		}
+		s.Tracer = tracer
+	} else {
+		tracing.SetGlobalTracer(s.Tracer)
+		if prometheusPort != 0 {
+			promMux := http.NewServeMux()
+			promMux.Handle("/metrics", promhttp.HandlerFor(
+				prometheus.DefaultGatherer,
+				promhttp.HandlerOpts{EnableOpenMetrics: true},
+			))
+			go func() {
+				if err := http.ListenAndServe(fmt.Sprintf(":%d", prometheusPort), promMux); err != nil {
+					logger.ErrorContext(context.Background(), "prometheus listener failed", slog.Any("err", err))
+				}
+			}()
+		}
+	}
 
 	opts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepalive.ServerParameters{
@@ -91,9 +168,7 @@ func (s *Server) Run() error {
 		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
 			PermitWithoutStream: true,
 		}),
-		grpc.UnaryInterceptor(
-			otgrpc.OpenTracingServerInterceptor(s.Tracer),
-		),
+		tracing.UnaryServerInterceptor(s.Tracer),
 	}
 
 	if tlsopt := tls.GetServerOpt(); tlsopt != nil {
@@ -106,32 +181,46 @@ func (s *Server) Run() error {
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.Port))
 	if err != nil {
-		log.Fatal().Msgf("failed to configure listener: %v", err)
+		logger.ErrorContext(context.Background(), "failed to configure listener", slog.Any("err", err))
+		os.Exit(1)
 	}
 
-	// Configure Prometheus exports and tracing.
-	tracing.Configure("profile", s.PrometheusPort)
-
-	// register the service
-	// jsonFile, err := os.Open("config.json")
-	// if err != nil {
-	// 	fmt.Println(err)
-	// }
-
-	// defer jsonFile.Close()
-
-	// byteValue, _ := ioutil.ReadAll(jsonFile)
-
-	// var result map[string]string
-	// json.Unmarshal([]byte(byteValue), &result)
-
 	err = s.Registry.Register(name, s.uuid, s.IpAddr, s.Port)
 	if err != nil {
 		return fmt.Errorf("failed register: %v", err)
 	}
-	log.Info().Msg("Successfully registered in consul")
+	logger.InfoContext(context.Background(), "successfully registered in consul")
 
-	return srv.Serve(lis)
+	if !s.SinglePortMode {
+		return srv.Serve(lis)
+	}
+
+	// Single-port mode: mux gRPC and /metrics onto one h2c listener. The
+	// grpc-gateway REST handlers for pb.ProfileServer are NOT part of this
+	// series -- no pb.ProfileServer gateway stubs have been generated yet,
+	// so there is nothing to mount here. Single-port mode today only merges
+	// gRPC and Prometheus; REST is tracked as follow-up work, not silently
+	// dropped.
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/metrics", promhttp.HandlerFor(
+		prometheus.DefaultGatherer,
+		promhttp.HandlerOpts{EnableOpenMetrics: true},
+	))
+
+	h2cSrv := &http.Server{Handler: h2c.NewHandler(grpcOrHTTPHandler(srv, httpMux), &http2.Server{})}
+	return h2cSrv.Serve(lis)
+}
+
+// grpcOrHTTPHandler dispatches gRPC requests to srv and everything else to
+// httpHandler, so both can share a single h2c listener.
+func grpcOrHTTPHandler(srv *grpc.Server, httpHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			srv.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
 }
 
 // Shutdown cleans up any processes
@@ -142,15 +231,23 @@ func (s *Server) Shutdown() {
 // GetProfiles returns hotel profiles for requested IDs
 func (s *Server) GetProfiles(ctx context.Context, req *pb.Request) (*pb.Result, error) {
 	serverSpan := tracing.StartServerSpan(ctx, "GetProfiles")
-	defer serverSpan.Finish()
-
-	// session, err := mgo.Dial("mongodb-profile")
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// defer session.Close()
+	serverRequests.WithLabelValues("GetProfiles").Inc()
+	var clientElapsed float64
+	var clientElapsedMutex sync.Mutex
+	defer func() {
+		elapsed := serverSpan.Finish()
+		tracing.ObserveDuration(serverLatency, serverSpan, elapsed, "GetProfiles")
+
+		clientElapsedMutex.Lock()
+		exclusive := elapsed - clientElapsed
+		clientElapsedMutex.Unlock()
+		if exclusive < 0 {
+			exclusive = 0
+		}
+		tracing.ObserveDuration(serverExclusiveLatency, serverSpan, exclusive, "GetProfiles")
+	}()
 
-	log.Trace().Msgf("In GetProfiles")
+	logger.DebugContext(ctx, "GetProfiles start", slog.Int("hotel_id_count", len(req.HotelIds)))
 
 	res := new(pb.Result)
 	hotels := make([]*pb.Hotel, 0)
@@ -166,55 +263,120 @@ func (s *Server) GetProfiles(ctx context.Context, req *pb.Request) (*pb.Result,
 	}
 	clientSpan := tracing.StartClientSpan(ctx, serverSpan, "memcached", "get_profile")
 	resMap, err := s.MemcClient.GetMulti(hotelIds)
-	clientSpan.Finish()
+	memcElapsed := clientSpan.Finish()
+	clientElapsedMutex.Lock()
+	clientElapsed += memcElapsed
+	clientElapsedMutex.Unlock()
+	clientRequests.WithLabelValues("profile", "memcached").Inc()
+	tracing.ObserveDuration(clientLatency, clientSpan, memcElapsed, "profile", "memcached")
 	if err != nil && err != memcache.ErrCacheMiss {
-		log.Panic().Msgf("Tried to get hotelIds [%v], but got memmcached error = %s", hotelIds, err)
+		logger.ErrorContext(ctx, "memcached GetMulti failed", slog.Any("hotel_ids", hotelIds), slog.Any("err", err))
+		panic(err)
 	} else {
 		for hotelId, item := range resMap {
 			profileStr := string(item.Value)
-			log.Trace().Msgf("memc hit with %v", profileStr)
+			logger.DebugContext(ctx, "memcached hit", slog.String("hotel_id", hotelId), slog.String("value", profileStr))
+			delete(profileMap, hotelId)
+
+			if profileStr == negativeCacheValue {
+				profileNegativeCacheHits.Inc()
+				continue
+			}
 
 			hotelProf := new(pb.Hotel)
 			json.Unmarshal(item.Value, hotelProf)
 			hotels = append(hotels, hotelProf)
-			delete(profileMap, hotelId)
 		}
 
 		wg.Add(len(profileMap))
 		for hotelId := range profileMap {
 			go func(hotelId string) {
-				session := s.MongoSession.Copy()
-				defer session.Close()
-				c := session.DB("profile-db").C("hotels")
+				defer wg.Done()
 
-				hotelProf := new(pb.Hotel)
 				clientSpan := tracing.StartClientSpan(ctx, serverSpan, "mongo", "profile")
-				err := c.Find(bson.M{"id": hotelId}).One(&hotelProf)
+				leader := false
+				v, err, shared := hotelGroup.Do(hotelId, func() (interface{}, error) {
+					leader = true
+					return s.fetchHotelProfile(ctx, hotelId)
+				})
 				clientSpan.Finish()
-
-				if err != nil {
-					log.Error().Msgf("Failed get hotels data: ", err)
+				if shared && !leader {
+					profileSingleflightShared.Inc()
 				}
 
-				mutex.Lock()
-				hotels = append(hotels, hotelProf)
-				mutex.Unlock()
+				// Every caller -- the singleflight leader and any followers
+				// sharing its result -- waited on the same Mongo round trip,
+				// so every caller charges it to its own clientElapsed and
+				// records its own client span/exemplar, even though only the
+				// leader actually queried Mongo.
+				result := v.(*hotelFetchResult)
+				clientElapsedMutex.Lock()
+				clientElapsed += result.elapsed
+				clientElapsedMutex.Unlock()
+				clientRequests.WithLabelValues("profile", "mongo").Inc()
+				tracing.ObserveDuration(clientLatency, clientSpan, result.elapsed, "profile", "mongo")
 
-				profJson, err := json.Marshal(hotelProf)
 				if err != nil {
-					log.Error().Msgf("Failed to marshal hotel [id: %v] with err:", hotelProf.Id, err)
+					if err != errHotelNotFound {
+						logger.ErrorContext(ctx, "failed to get hotel data", slog.String("hotel_id", hotelId), slog.Any("err", err))
+					}
+					return
 				}
-				memcStr := string(profJson)
 
-				// write to memcached
-				go s.MemcClient.Set(&memcache.Item{Key: hotelId, Value: []byte(memcStr)})
-				defer wg.Done()
+				mutex.Lock()
+				hotels = append(hotels, result.hotel)
+				mutex.Unlock()
 			}(hotelId)
 		}
 	}
 	wg.Wait()
 
 	res.Hotels = hotels
-	log.Trace().Msgf("In GetProfiles after getting resp")
+	logger.DebugContext(ctx, "GetProfiles done", slog.Int("hotel_count", len(hotels)))
 	return res, nil
 }
+
+// hotelFetchResult is what fetchHotelProfile returns through hotelGroup.Do.
+// Only the singleflight leader actually runs fetchHotelProfile, but every
+// caller -- leader and followers alike -- needs to know how long the Mongo
+// round trip it waited on took, so it can charge that time to its own
+// clientElapsed and client span instead of only the leader's.
+type hotelFetchResult struct {
+	hotel   *pb.Hotel
+	elapsed float64
+}
+
+// fetchHotelProfile looks up hotelId in Mongo and populates memcached
+// (positive on success, a short-TTL negative-cache sentinel on
+// errHotelNotFound) so subsequent requests skip Mongo. It is meant to be
+// called through hotelGroup so concurrent requests for the same hotelId
+// share one lookup.
+func (s *Server) fetchHotelProfile(ctx context.Context, hotelId string) (*hotelFetchResult, error) {
+	collection := s.MongoClient.Database("profile-db").Collection("hotels")
+
+	hotelProf := new(pb.Hotel)
+	start := time.Now()
+	err := collection.FindOne(ctx, bson.D{{Key: "id", Value: hotelId}}).Decode(hotelProf)
+	result := &hotelFetchResult{elapsed: time.Since(start).Seconds()}
+
+	if err == mongo.ErrNoDocuments {
+		s.MemcClient.Set(&memcache.Item{
+			Key:        hotelId,
+			Value:      []byte(negativeCacheValue),
+			Expiration: negativeCacheExpiration,
+		})
+		return result, errHotelNotFound
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if profJson, err := json.Marshal(hotelProf); err != nil {
+		logger.ErrorContext(ctx, "failed to marshal hotel", slog.Any("hotel", hotelLogValue{hotelProf}), slog.Any("err", err))
+	} else {
+		go s.MemcClient.Set(&memcache.Item{Key: hotelId, Value: profJson})
+	}
+
+	result.hotel = hotelProf
+	return result, nil
+}
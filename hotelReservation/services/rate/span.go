@@ -3,16 +3,17 @@ package rate
 import (
 	"context"
 	"strings"
-	"time"
 
-	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/harlow/go-micro-services/tracing"
 )
 
-// Span is a wrapper that generates both Opentracing traces and Prometheus metrics.
+// Span is a wrapper that generates both tracing spans and Prometheus metrics.
+// It works with either tracing backend (OpenTracing or OpenTelemetry),
+// since tracing.Span is implemented by both.
 type Span struct {
-	start            time.Time
-	span             opentracing.Span
+	span             tracing.Span
 	labels           []string
 	requestCounter   *prometheus.CounterVec
 	latencyHistogram *prometheus.HistogramVec
@@ -20,25 +21,24 @@ type Span struct {
 
 func StartSpan(ctx context.Context, labels []string, requestCounter *prometheus.CounterVec,
 	latencyHistogram *prometheus.HistogramVec) *Span {
-	span := Span{
-		start:            time.Now(),
+	return &Span{
+		span:             tracing.StartServerSpan(ctx, strings.Join(labels, "_")),
 		labels:           labels,
 		requestCounter:   requestCounter,
 		latencyHistogram: latencyHistogram,
 	}
-	span.span, _ = opentracing.StartSpanFromContext(ctx, strings.Join(labels, "_"))
-	return &span
 }
 
-func (span *Span) SetTag(key, value string) {
-	span.span.SetTag(key, value)
+func (s *Span) SetTag(key, value string) {
+	s.span.SetTag(key, value)
 }
 
-// Finish tarminates the span and observes metrics. Returns elapsed time in seconds.
-func (span *Span) Finish() float64 {
-	span.span.Finish()
-	span.requestCounter.WithLabelValues(span.labels...).Inc()
-	elapsed := time.Now().Sub(span.start).Seconds()
-	span.latencyHistogram.WithLabelValues(span.labels...).Observe(elapsed)
+// Finish terminates the span and observes metrics. Returns elapsed time in seconds.
+// The observation carries the span's trace ID as a Prometheus exemplar when
+// the span is sampled, so a latency spike can be clicked through to its trace.
+func (s *Span) Finish() float64 {
+	elapsed := s.span.Finish()
+	s.requestCounter.WithLabelValues(s.labels...).Inc()
+	tracing.ObserveDuration(s.latencyHistogram, s.span, elapsed, s.labels...)
 	return elapsed
 }
@@ -0,0 +1,21 @@
+package tracing
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// ObserveDuration records seconds against hv's labelValues, stamping the
+// observation with span's trace/span ID as a Prometheus exemplar when span
+// is sampled. Unsampled (or nil) spans fall back to a plain Observe, since an
+// exemplar pointing at a trace nobody kept is worse than no exemplar.
+func ObserveDuration(hv *prometheus.HistogramVec, span Span, seconds float64, labelValues ...string) {
+	observer := hv.WithLabelValues(labelValues...)
+	if span != nil && span.Sampled() {
+		if eo, ok := observer.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(seconds, prometheus.Labels{
+				"trace_id": span.TraceID(),
+				"span_id":  span.SpanID(),
+			})
+			return
+		}
+	}
+	observer.Observe(seconds)
+}
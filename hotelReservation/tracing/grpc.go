@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+
+	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
+)
+
+// UnaryServerInterceptor returns the gRPC server interceptor matching t's
+// backend, so Server.Run doesn't need a type switch of its own.
+func UnaryServerInterceptor(t Tracer) grpc.ServerOption {
+	if ot, ok := t.(*openTracingTracer); ok {
+		return grpc.UnaryInterceptor(otgrpc.OpenTracingServerInterceptor(ot.tracer))
+	}
+	if oc, ok := t.(*otelTracer); ok {
+		return grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor(otelgrpc.WithPropagators(oc.propagator)))
+	}
+	return grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor())
+}
+
+// UnaryClientInterceptor returns the gRPC client dial option matching t's
+// backend.
+func UnaryClientInterceptor(t Tracer) grpc.DialOption {
+	if ot, ok := t.(*openTracingTracer); ok {
+		return grpc.WithUnaryInterceptor(otgrpc.OpenTracingClientInterceptor(ot.tracer))
+	}
+	if oc, ok := t.(*otelTracer); ok {
+		return grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor(otelgrpc.WithPropagators(oc.propagator)))
+	}
+	return grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor())
+}
@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	"github.com/uber/jaeger-client-go/zipkin"
+)
+
+// openTracingTracer wraps an opentracing.Tracer whose HTTPHeaders carriers
+// (which otgrpc uses for gRPC metadata) are injected and extracted as B3
+// headers in place of Jaeger's native uber-trace-id header, so its trace
+// context can be joined by B3-aware peers, including the OTel backend.
+type openTracingTracer struct {
+	tracer opentracing.Tracer
+	closer interface{ Close() error }
+}
+
+func (t *openTracingTracer) Backend() Backend { return OpenTracingBackend }
+
+func newOpenTracingTracer(serviceName string) (Tracer, error) {
+	zipkinPropagator := zipkin.NewZipkinB3HTTPHeaderPropagator()
+	cfg, err := jaegercfg.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.ServiceName = serviceName
+
+	tracer, closer, err := cfg.NewTracer(
+		jaegercfg.Injector(opentracing.HTTPHeaders, zipkinPropagator),
+		jaegercfg.Extractor(opentracing.HTTPHeaders, zipkinPropagator),
+		jaegercfg.ZipkinSharedRPCSpan(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	opentracing.SetGlobalTracer(tracer)
+	return &openTracingTracer{tracer: tracer, closer: closer}, nil
+}
+
+func (t *openTracingTracer) startSpan(ctx context.Context, parent Span, operationName string) Span {
+	var opts []opentracing.StartSpanOption
+	if ps, ok := parent.(*openTracingSpan); ok {
+		opts = append(opts, opentracing.ChildOf(ps.span.Context()))
+	}
+	span, _ := opentracing.StartSpanFromContextWithTracer(ctx, t.tracer, operationName, opts...)
+	return &openTracingSpan{span: span, start: time.Now()}
+}
+
+func (t *openTracingTracer) spanFromContext(ctx context.Context) Span {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	return &openTracingSpan{span: span, start: time.Now()}
+}
+
+type openTracingSpan struct {
+	span  opentracing.Span
+	start time.Time
+}
+
+func (s *openTracingSpan) SetTag(key, value string) {
+	s.span.SetTag(key, value)
+}
+
+func (s *openTracingSpan) Finish() float64 {
+	s.span.Finish()
+	return time.Since(s.start).Seconds()
+}
+
+func (s *openTracingSpan) TraceID() string {
+	if sc, ok := s.span.Context().(jaeger.SpanContext); ok {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+func (s *openTracingSpan) SpanID() string {
+	if sc, ok := s.span.Context().(jaeger.SpanContext); ok {
+		return sc.SpanID().String()
+	}
+	return ""
+}
+
+func (s *openTracingSpan) Sampled() bool {
+	if sc, ok := s.span.Context().(jaeger.SpanContext); ok {
+		return sc.IsSampled()
+	}
+	return false
+}
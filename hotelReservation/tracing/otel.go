@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	otelsvc "github.com/harlow/go-micro-services/services/otel"
+)
+
+// otelTracer wraps an OpenTelemetry tracer built from a TracerProvider
+// exporting over OTLP.
+type otelTracer struct {
+	tracer     oteltrace.Tracer
+	shutdown   func(context.Context) error
+	propagator propagation.TextMapPropagator
+}
+
+func (t *otelTracer) Backend() Backend { return OTelBackend }
+
+func newOTelTracer(serviceName string) (Tracer, error) {
+	tp, shutdown, err := otelsvc.NewTracerProvider(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	// Accept both W3C traceparent and B3 on ingest, matching the OpenTracing
+	// backend's zipkin propagator, so mixed OpenTracing/OTel deployments can
+	// still join traces during the migration.
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		otelsvc.B3Propagator(),
+	)
+	// Install it as the OTel SDK's global propagator: otelgrpc's interceptors
+	// (and anything else using otel.GetTextMapPropagator) default to a no-op
+	// otherwise, so trace context would never cross the wire.
+	otel.SetTextMapPropagator(propagator)
+
+	return &otelTracer{
+		tracer:     tp.Tracer(serviceName),
+		shutdown:   shutdown,
+		propagator: propagator,
+	}, nil
+}
+
+func (t *otelTracer) startSpan(ctx context.Context, parent Span, operationName string) Span {
+	if ps, ok := parent.(*otelSpan); ok {
+		ctx = oteltrace.ContextWithSpan(ctx, ps.span)
+	}
+	_, span := t.tracer.Start(ctx, operationName)
+	return &otelSpan{span: span, start: time.Now()}
+}
+
+func (t *otelTracer) spanFromContext(ctx context.Context) Span {
+	span := oteltrace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+	return &otelSpan{span: span, start: time.Now()}
+}
+
+type otelSpan struct {
+	span  oteltrace.Span
+	start time.Time
+}
+
+func (s *otelSpan) SetTag(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+func (s *otelSpan) Finish() float64 {
+	s.span.End()
+	return time.Since(s.start).Seconds()
+}
+
+func (s *otelSpan) TraceID() string {
+	return s.span.SpanContext().TraceID().String()
+}
+
+func (s *otelSpan) SpanID() string {
+	return s.span.SpanContext().SpanID().String()
+}
+
+func (s *otelSpan) Sampled() bool {
+	return s.span.SpanContext().IsSampled()
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+// servePrometheus exposes /metrics on prometheusPort in a background
+// goroutine, matching the historical bjornleffler/tracing.Configure behavior.
+func servePrometheus(prometheusPort int) {
+	if prometheusPort == 0 {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(
+		prometheus.DefaultGatherer,
+		promhttp.HandlerOpts{EnableOpenMetrics: true},
+	))
+	go func() {
+		addr := fmt.Sprintf(":%d", prometheusPort)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Error().Msgf("prometheus listener on %s failed: %v", addr, err)
+		}
+	}()
+}
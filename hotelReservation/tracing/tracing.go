@@ -0,0 +1,138 @@
+// Package tracing provides a thin, backend-agnostic wrapper around
+// OpenTracing and OpenTelemetry so the rest of the services can start spans
+// without caring which backend is active.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Backend selects which tracing implementation StartServerSpan and
+// StartClientSpan dispatch to.
+type Backend string
+
+const (
+	// OpenTracingBackend drives spans through github.com/opentracing/opentracing-go,
+	// propagated as B3 headers. This is the long-standing default.
+	OpenTracingBackend Backend = "opentracing"
+	// OTelBackend drives spans through go.opentelemetry.io/otel, exported via OTLP.
+	OTelBackend Backend = "otel"
+)
+
+// BackendFromEnv reads TRACING_BACKEND, defaulting to OpenTracingBackend when
+// unset or unrecognized.
+func BackendFromEnv() Backend {
+	switch Backend(os.Getenv("TRACING_BACKEND")) {
+	case OTelBackend:
+		return OTelBackend
+	default:
+		return OpenTracingBackend
+	}
+}
+
+// Tracer is implemented by both the OpenTracing and OpenTelemetry backends.
+type Tracer interface {
+	// Backend reports which implementation this tracer wraps.
+	Backend() Backend
+}
+
+// Span abstracts an in-flight trace span so callers don't need to know which
+// backend produced it.
+type Span interface {
+	// SetTag attaches a string tag/attribute to the span.
+	SetTag(key, value string)
+	// Finish ends the span and returns the elapsed time in seconds.
+	Finish() float64
+	// TraceID returns the hex-encoded trace ID, or "" if there is no active span.
+	TraceID() string
+	// SpanID returns the hex-encoded span ID, or "" if there is no active span.
+	SpanID() string
+	// Sampled reports whether this span is being sampled, so callers can decide
+	// whether it's worth attaching as a Prometheus exemplar.
+	Sampled() bool
+}
+
+var global Tracer
+
+// SetGlobalTracer installs the tracer returned by Configure as the tracer used
+// by StartServerSpan and StartClientSpan.
+func SetGlobalTracer(t Tracer) {
+	global = t
+}
+
+// GlobalTracer returns the tracer installed by SetGlobalTracer, or nil if none
+// has been installed yet.
+func GlobalTracer() Tracer {
+	return global
+}
+
+// Configure builds a Tracer for the given backend and wires up the
+// Prometheus exporter on prometheusPort. It also installs the result as the
+// global tracer, matching the historical bjornleffler/tracing.Configure
+// behavior relied on by Server.Run.
+func Configure(serviceName string, backend Backend, prometheusPort int) (Tracer, error) {
+	var t Tracer
+	var err error
+	switch backend {
+	case OTelBackend:
+		t, err = newOTelTracer(serviceName)
+	default:
+		t, err = newOpenTracingTracer(serviceName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure %s tracer: %v", backend, err)
+	}
+	SetGlobalTracer(t)
+	servePrometheus(prometheusPort)
+	return t, nil
+}
+
+// StartServerSpan starts a span for an incoming server request using the
+// global tracer.
+func StartServerSpan(ctx context.Context, operationName string) Span {
+	return startSpan(ctx, global, nil, operationName)
+}
+
+// StartClientSpan starts a span for an outgoing request to component (e.g.
+// "mongo", "memcached"), as a child of parent.
+func StartClientSpan(ctx context.Context, parent Span, component, operationName string) Span {
+	span := startSpan(ctx, global, parent, operationName)
+	span.SetTag("component", component)
+	return span
+}
+
+// SpanFromContext returns the span active in ctx for whichever backend is
+// configured, or nil if there is none. Unlike StartServerSpan/StartClientSpan
+// it never creates a new span — it's for read-only access, such as log
+// correlation, from code that only has a context.
+func SpanFromContext(ctx context.Context) Span {
+	switch tr := global.(type) {
+	case *otelTracer:
+		return tr.spanFromContext(ctx)
+	case *openTracingTracer:
+		return tr.spanFromContext(ctx)
+	default:
+		return nil
+	}
+}
+
+func startSpan(ctx context.Context, t Tracer, parent Span, operationName string) Span {
+	switch tr := t.(type) {
+	case *otelTracer:
+		return tr.startSpan(ctx, parent, operationName)
+	case *openTracingTracer:
+		return tr.startSpan(ctx, parent, operationName)
+	default:
+		return noopSpan{}
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetTag(key, value string) {}
+func (noopSpan) Finish() float64           { return 0 }
+func (noopSpan) TraceID() string           { return "" }
+func (noopSpan) SpanID() string            { return "" }
+func (noopSpan) Sampled() bool             { return false }